@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// dismissedEvents 记录一次页面渲染过程中自动处理掉的弹窗和横幅，便于在 Result 中展示。
+type dismissedEvents struct {
+	Dialogs int
+	Banners int
+}
+
+// listenForDialogs 注册一个监听器，自动接受页面弹出的 alert/confirm/prompt 对话框，
+// 避免其挂起 waitForPageLoad 长达 30 秒。
+func listenForDialogs(ctx context.Context, dismissed *dismissedEvents) {
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		if _, ok := ev.(*page.EventJavascriptDialogOpening); ok {
+			dismissed.Dialogs++
+			go func() {
+				_ = chromedp.Run(ctx, page.HandleJavaScriptDialog(true))
+			}()
+		}
+	})
+}
+
+// dismissBanners 依次点击 -dismiss-selectors 指定的元素（例如 cookie 同意按钮），
+// 忽略页面上不存在的选择器。
+func dismissBanners(ctx context.Context, selectors string, dismissed *dismissedEvents) error {
+	if selectors == "" {
+		return nil
+	}
+	for _, selector := range strings.Split(selectors, ",") {
+		selector = strings.TrimSpace(selector)
+		if selector == "" {
+			continue
+		}
+		if err := chromedp.Run(ctx, chromedp.Click(selector, chromedp.ByQuery)); err == nil {
+			dismissed.Banners++
+		}
+	}
+	return nil
+}
+
+// runUserScript 在当前页面上执行 -user-script 指定的自定义 JS，用于处理注册表之外的弹窗/横幅。
+func runUserScript(ctx context.Context, scriptPath string) error {
+	if scriptPath == "" {
+		return nil
+	}
+	script, err := os.ReadFile(scriptPath)
+	if err != nil {
+		return err
+	}
+	return chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		_, exp, err := runtime.Evaluate(string(script)).Do(ctx)
+		if err != nil {
+			return err
+		}
+		if exp != nil {
+			return fmt.Errorf("user script failed: %v", exp)
+		}
+		return nil
+	}))
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/ericpauley/go-quantize/quantize"
+	"github.com/nfnt/resize"
+)
+
+// EncodeOpts 控制截图编码时使用的参数。
+type EncodeOpts struct {
+	Quality  int // JPEG 质量 (1-100)
+	Colors   int // GIF 调色板颜色数 (2-256)
+	MaxWidth int // 编码前按比例缩小到的最大宽度，0 表示不缩放
+}
+
+// ImageEncoder 将一张原始（PNG 格式）截图编码为目标格式。
+type ImageEncoder interface {
+	// Encode 返回编码后的数据、对应的 MIME 类型，以及遇到的错误。
+	Encode(raw []byte, opts EncodeOpts) (data []byte, mime string, err error)
+}
+
+func decodeAndResize(raw []byte, maxWidth int) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode screenshot: %w", err)
+	}
+	if maxWidth > 0 && img.Bounds().Dx() > maxWidth {
+		img = resize.Resize(uint(maxWidth), 0, img, resize.Lanczos3)
+	}
+	return img, nil
+}
+
+// pngEncoder 是默认的编码器，对应重构前硬编码的 PNG 行为。
+type pngEncoder struct{}
+
+func (pngEncoder) Encode(raw []byte, opts EncodeOpts) ([]byte, string, error) {
+	img, err := decodeAndResize(raw, opts.MaxWidth)
+	if err != nil {
+		return nil, "", err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, "", fmt.Errorf("failed to encode PNG: %w", err)
+	}
+	return buf.Bytes(), "image/png", nil
+}
+
+// jpegEncoder 以指定质量编码为 JPEG。
+type jpegEncoder struct{}
+
+func (jpegEncoder) Encode(raw []byte, opts EncodeOpts) ([]byte, string, error) {
+	img, err := decodeAndResize(raw, opts.MaxWidth)
+	if err != nil {
+		return nil, "", err
+	}
+	quality := opts.Quality
+	if quality <= 0 || quality > 100 {
+		quality = 85
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, "", fmt.Errorf("failed to encode JPEG: %w", err)
+	}
+	return buf.Bytes(), "image/jpeg", nil
+}
+
+// gifEncoder 使用 median-cut 量化生成调色板 GIF。
+type gifEncoder struct{}
+
+func (gifEncoder) Encode(raw []byte, opts EncodeOpts) ([]byte, string, error) {
+	img, err := decodeAndResize(raw, opts.MaxWidth)
+	if err != nil {
+		return nil, "", err
+	}
+	colors := opts.Colors
+	if colors < 2 {
+		colors = 2
+	} else if colors > 256 {
+		colors = 256
+	}
+
+	quantizer := quantize.MedianCutQuantizer{Aggregation: quantize.Mean}
+	palette := quantizer.Quantize(make(color.Palette, 0, colors), img)
+
+	paletted := image.NewPaletted(img.Bounds(), palette)
+	draw.Draw(paletted, img.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	var buf bytes.Buffer
+	if err := gif.Encode(&buf, paletted, nil); err != nil {
+		return nil, "", fmt.Errorf("failed to encode GIF: %w", err)
+	}
+	return buf.Bytes(), "image/gif", nil
+}
+
+// newImageEncoder 根据 -image-format 标志选择对应的编码器实现。
+func newImageEncoder(format string) (ImageEncoder, error) {
+	switch format {
+	case "", "png":
+		return pngEncoder{}, nil
+	case "jpg", "jpeg":
+		return jpegEncoder{}, nil
+	case "gif":
+		return gifEncoder{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported image format %q, want png, jpg or gif", format)
+	}
+}
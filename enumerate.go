@@ -0,0 +1,133 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SiteDef 描述一个待检测的站点，格式借鉴了 Sherlock/DetectDee 的站点注册表。
+type SiteDef struct {
+	URL     string `json:"url"`               // 含 "{}" 占位符的账号主页地址
+	ErrType string `json:"errType"`           // 判定方式: status_code, message 或 response_url
+	ErrCode int    `json:"errCode,omitempty"` // errType=status_code 时，"未注册"对应的状态码
+	ErrMsg  string `json:"errMsg,omitempty"`  // errType=message 时，"未注册"页面包含的文本
+	ErrURL  string `json:"errUrl,omitempty"`  // errType=response_url 时，"未注册"会跳转到的 URL 片段
+}
+
+// loadSiteRegistry 读取 -sites 指定的 JSON 注册表文件。
+func loadSiteRegistry(path string) (map[string]SiteDef, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read site registry: %w", err)
+	}
+	var sites map[string]SiteDef
+	if err := json.Unmarshal(data, &sites); err != nil {
+		return nil, fmt.Errorf("failed to parse site registry: %w", err)
+	}
+	return sites, nil
+}
+
+// EnumResult 在 Result 的基础上附加站点名和账号认领状态。
+type EnumResult struct {
+	Result
+	SiteName string
+	Status   string // claimed, available 或 unknown
+}
+
+// fetchBody 发起一次独立的 GET 请求，读取响应正文用于 errType=message 的判定。
+func fetchBody(url string, timeout time.Duration) (string, error) {
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// classifyAccount 根据站点定义的 errType 判断账号是否已被认领。
+func classifyAccount(def SiteDef, result Result, timeout time.Duration) string {
+	switch def.ErrType {
+	case "status_code":
+		if result.StatusCode == def.ErrCode {
+			return "available"
+		}
+		return "claimed"
+	case "response_url":
+		if result.URL == "" {
+			return "unknown"
+		}
+		if strings.Contains(result.URL, def.ErrURL) {
+			return "available"
+		}
+		return "claimed"
+	case "message":
+		body, err := fetchBody(result.URL, timeout)
+		if err != nil {
+			return "unknown"
+		}
+		if strings.Contains(body, def.ErrMsg) {
+			return "available"
+		}
+		return "claimed"
+	default:
+		return "unknown"
+	}
+}
+
+// runEnumerateMode 针对单个用户名，在站点注册表中的每个站点上运行既有的截图流水线，
+// 并根据各站点的判定规则给出 claimed/available/unknown 的分类结果。
+func runEnumerateMode(username, sitesPath string, concurrency int, timeout time.Duration, maxRetries int, opts ProcessOptions) ([]EnumResult, error) {
+	sites, err := loadSiteRegistry(sitesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(sites))
+	for name := range sites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	urls := make([]string, 0, len(names))
+	urlToSite := make(map[string]string, len(names))
+	for _, name := range names {
+		url := strings.ReplaceAll(sites[name].URL, "{}", username)
+		urls = append(urls, url)
+		urlToSite[url] = name
+	}
+
+	results := processURLsConcurrently(urls, concurrency, timeout, maxRetries, opts, nil)
+
+	enumResults := make([]EnumResult, 0, len(results))
+	for _, result := range results {
+		name := urlToSite[result.OriginalURL]
+		def := sites[name]
+		status := "unknown"
+		if result.StatusCode != -1 {
+			status = classifyAccount(def, result, timeout)
+		}
+		enumResults = append(enumResults, EnumResult{Result: result, SiteName: name, Status: status})
+	}
+
+	sort.Slice(enumResults, func(i, j int) bool { return enumResults[i].SiteName < enumResults[j].SiteName })
+
+	return enumResults, nil
+}
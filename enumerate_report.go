@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+// generateEnumerateReport 生成用户名枚举模式的 HTML 报告，支持按认领状态筛选。
+func generateEnumerateReport(username string, results []EnumResult) {
+	claimedCount := 0
+	for _, r := range results {
+		if r.Status == "claimed" {
+			claimedCount++
+		}
+	}
+
+	htmlContent := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <title>CheckURL enumerate - %s</title>
+    <style>
+        body { font-family: Arial, sans-serif; margin: 0; padding: 20px; }
+        table { border-collapse: collapse; width: 100%%; table-layout: auto; }
+        th, td { border: 1px solid #ddd; padding: 8px; text-align: left; vertical-align: top; word-wrap: break-word; }
+        th { background-color: #f2f2f2; }
+        .screenshot { max-width: 50%%; height: auto; }
+        .status-claimed { color: green; font-weight: bold; }
+        .status-available { color: gray; }
+        .status-unknown { color: orange; }
+        .summary { background-color: #e6f3ff; padding: 10px; margin-bottom: 20px; border-radius: 5px; }
+    </style>
+</head>
+<body>
+    <div class="summary">
+        <h2>用户名枚举结果: %s</h2>
+        <p>共检测 %d 个站点，%d 个疑似已注册</p>
+        <label><input type="checkbox" id="claimedOnly" onchange="filterClaimed()"> 只显示已认领的账号</label>
+    </div>
+    <table id="resultsTable">
+        <tr>
+            <th>站点</th>
+            <th>URL</th>
+            <th>状态</th>
+            <th>截图</th>
+        </tr>
+`, username, username, len(results), claimedCount)
+
+	for _, r := range results {
+		var screenshotHTML string
+		if r.Screenshot != "" {
+			mime := r.ScreenshotMIME
+			if mime == "" {
+				mime = "image/png"
+			}
+			screenshotHTML = fmt.Sprintf(`<img class="screenshot" src="data:%s;base64,%s" alt="Screenshot">`, mime, r.Screenshot)
+		} else {
+			screenshotHTML = "No screenshot available..."
+		}
+
+		htmlContent += fmt.Sprintf(`
+        <tr data-status="%s">
+            <td>%s</td>
+            <td><a href="%s" target="_blank">%s</a></td>
+            <td class="status-%s">%s</td>
+            <td>%s</td>
+        </tr>
+`, r.Status, r.SiteName, r.URL, r.URL, r.Status, r.Status, screenshotHTML)
+	}
+
+	htmlContent += `
+    </table>
+    <script>
+        function filterClaimed() {
+            var onlyClaimed = document.getElementById('claimedOnly').checked;
+            var rows = document.getElementById('resultsTable').rows;
+            for (var i = 1; i < rows.length; i++) {
+                var row = rows[i];
+                row.style.display = (!onlyClaimed || row.dataset.status === 'claimed') ? '' : 'none';
+            }
+        }
+    </script>
+</body>
+</html>
+`
+
+	if err := os.WriteFile("enumerate_results.html", []byte(htmlContent), 0644); err != nil {
+		log.Fatal("Failed to save enumerate report:", err)
+	}
+	fmt.Println("Results saved to enumerate_results.html")
+}
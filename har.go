@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+const harDir = "har"
+
+// harEntry 记录单个网络请求在 HAR 条目中需要的字段，随着各类网络事件到达逐步填充。
+type harEntry struct {
+	RequestID  network.RequestID
+	URL        string
+	Method     string
+	Status     int64
+	MimeType   string
+	Bytes      int64
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Failed     bool
+	ErrorText  string
+}
+
+// networkLog 在一次 chromedp 会话期间累积所有请求的 HAR 条目。
+type networkLog struct {
+	mu      sync.Mutex
+	entries map[network.RequestID]*harEntry
+}
+
+func newNetworkLog() *networkLog {
+	return &networkLog{entries: make(map[network.RequestID]*harEntry)}
+}
+
+// listen 在给定的 chromedp 上下文上注册网络事件监听器，收集请求/响应信息。
+func (nl *networkLog) listen(ctx context.Context) {
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		switch e := ev.(type) {
+		case *network.EventRequestWillBeSent:
+			nl.mu.Lock()
+			nl.entries[e.RequestID] = &harEntry{
+				RequestID: e.RequestID,
+				URL:       e.Request.URL,
+				Method:    e.Request.Method,
+				StartedAt: e.WallTime.Time(),
+			}
+			nl.mu.Unlock()
+		case *network.EventResponseReceived:
+			nl.mu.Lock()
+			if entry, ok := nl.entries[e.RequestID]; ok {
+				entry.Status = e.Response.Status
+				entry.MimeType = e.Response.MimeType
+			}
+			nl.mu.Unlock()
+		case *network.EventLoadingFinished:
+			nl.mu.Lock()
+			if entry, ok := nl.entries[e.RequestID]; ok {
+				entry.Bytes = int64(e.EncodedDataLength)
+				entry.FinishedAt = time.Now()
+			}
+			nl.mu.Unlock()
+		case *network.EventLoadingFailed:
+			nl.mu.Lock()
+			if entry, ok := nl.entries[e.RequestID]; ok {
+				entry.Failed = true
+				entry.ErrorText = e.ErrorText
+			}
+			nl.mu.Unlock()
+		}
+	})
+}
+
+// summary 汇总请求总数、传输字节数和 4xx/5xx 响应数，用于填充 Result。
+func (nl *networkLog) summary() (requests int, bytes int64, errorResponses int) {
+	nl.mu.Lock()
+	defer nl.mu.Unlock()
+	for _, entry := range nl.entries {
+		requests++
+		bytes += entry.Bytes
+		if entry.Status >= 400 {
+			errorResponses++
+		}
+	}
+	return requests, bytes, errorResponses
+}
+
+// HAR 1.2 类型定义，仅包含报告所需的最小字段集。
+type harLog struct {
+	Log harLogBody `json:"log"`
+}
+
+type harLogBody struct {
+	Version string         `json:"version"`
+	Creator harCreator     `json:"creator"`
+	Entries []harEntryJSON `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntryJSON struct {
+	StartedDateTime string         `json:"startedDateTime"`
+	Time            float64        `json:"time"`
+	Request         harRequest     `json:"request"`
+	Response        harResponse    `json:"response"`
+	Cache           map[string]any `json:"cache"`
+	Timings         harTimings     `json:"timings"`
+}
+
+type harRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+type harResponse struct {
+	Status   int64      `json:"status"`
+	Content  harContent `json:"content"`
+	BodySize int64      `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// harPathForURL 返回给定 URL 对应的 HAR 文件路径，按其 sha1 命名。
+func harPathForURL(url string) string {
+	sum := sha1.Sum([]byte(url))
+	return filepath.Join(harDir, hex.EncodeToString(sum[:])+".har")
+}
+
+// writeHAR 将收集到的网络事件序列化为 HAR 1.2 JSON 文件。
+func (nl *networkLog) writeHAR(url string) (string, error) {
+	if err := os.MkdirAll(harDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create HAR directory: %w", err)
+	}
+
+	nl.mu.Lock()
+	entries := make([]harEntryJSON, 0, len(nl.entries))
+	for _, e := range nl.entries {
+		duration := e.FinishedAt.Sub(e.StartedAt).Seconds() * 1000
+		if duration < 0 {
+			duration = 0
+		}
+		entries = append(entries, harEntryJSON{
+			StartedDateTime: e.StartedAt.Format(time.RFC3339Nano),
+			Time:            duration,
+			Request:         harRequest{Method: e.Method, URL: e.URL},
+			Response: harResponse{
+				Status:   e.Status,
+				BodySize: e.Bytes,
+				Content:  harContent{Size: e.Bytes, MimeType: e.MimeType},
+			},
+			Cache:   map[string]any{},
+			Timings: harTimings{Send: 0, Wait: duration, Receive: 0},
+		})
+	}
+	nl.mu.Unlock()
+
+	h := harLog{Log: harLogBody{
+		Version: "1.2",
+		Creator: harCreator{Name: "CheckURL", Version: Version},
+		Entries: entries,
+	}}
+
+	path := harPathForURL(url)
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal HAR: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write HAR file: %w", err)
+	}
+	return path, nil
+}
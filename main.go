@@ -7,6 +7,7 @@ import (
 	"flag"
 	"fmt"
 	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/chromedp"
 	"golang.org/x/text/encoding/unicode"
@@ -14,8 +15,6 @@ import (
 	"log"
 	"net/http"
 	"os"
-	"os/exec"
-	goruntime "runtime"
 	"strings"
 	"sync"
 	"time"
@@ -24,13 +23,20 @@ import (
 const Version = "v2.1.1"
 
 type Result struct {
-	URL           string
-	OriginalURL   string
-	Title         string
-	StatusCode    int
-	Screenshot    string
-	Accessible    bool
-	WasRedirected bool
+	URL              string
+	OriginalURL      string
+	Title            string
+	StatusCode       int
+	Screenshot       string
+	ScreenshotMIME   string
+	Accessible       bool
+	WasRedirected    bool
+	NetworkRequests  int
+	NetworkBytes     int64
+	NetworkErrors    int
+	HARPath          string
+	DialogsDismissed int
+	BannersDismissed int
 }
 
 func main() {
@@ -42,6 +48,22 @@ func main() {
 	help := flag.Bool("help", false, "Show help information")
 	timeout := flag.Duration("timeout", 180*time.Second, "Timeout for each URL")
 	maxRetries := flag.Int("max-retries", 3, "Maximum number of retries for each URL")
+	serveAddr := flag.String("serve", "", "Run as an interactive browsing proxy on this address (e.g. :8080) instead of batch mode")
+	geometry := flag.String("geometry", "1280x1024x256", "Screen geometry for -serve mode, as WxHxColors")
+	serveToken := flag.String("serve-token", "", "Access token required by -serve mode (auto-generated and printed if left empty)")
+	servePublic := flag.Bool("serve-public", false, "Allow -serve to bind all network interfaces instead of localhost only (dangerous: exposes an unauthenticated-by-network SSRF-capable proxy)")
+	imageFormat := flag.String("image-format", "png", "Screenshot encoding format: png, jpg or gif")
+	jpgQuality := flag.Int("jpg-quality", 85, "JPEG quality (1-100) when -image-format=jpg")
+	colors := flag.Int("colors", 256, "Palette size (2-256) when -image-format=gif")
+	maxWidth := flag.Int("max-width", 0, "Downscale screenshots to this width in pixels before encoding (0 disables)")
+	harEnabled := flag.Bool("har", false, "Capture network events and export a HAR file per URL under har/ (off by default for performance)")
+	mode := flag.String("mode", "", "Operating mode: leave empty for batch URL checking, or \"enumerate\" for username enumeration")
+	username := flag.String("username", "", "Username to look up across sites, required when -mode=enumerate")
+	sitesFile := flag.String("sites", "sites.json", "Path to the site registry JSON file, used when -mode=enumerate")
+	dismissSelectors := flag.String("dismiss-selectors", "", "Comma-separated CSS selectors to click after page load, e.g. for cookie/consent banners")
+	userScriptPath := flag.String("user-script", "", "Path to a JS file evaluated on every page for custom dialog/banner dismissal")
+	outputFormat := flag.String("output-format", "html", "Report output format: html, json, csv or jsonl")
+	checkpointPath := flag.String("checkpoint", "", "Path to a JSONL checkpoint file; URLs already recorded there are skipped, and each finished URL is appended to it as it completes")
 
 	// 解析命令行参数
 	flag.Parse()
@@ -52,6 +74,47 @@ func main() {
 		os.Exit(0)
 	}
 
+	// -serve 模式下启动交互式浏览代理，不读取 URL 列表
+	if *serveAddr != "" {
+		token := *serveToken
+		if token == "" {
+			token = newSessionID()
+			log.Printf("No -serve-token given, generated one for this run: %s", token)
+			log.Printf("Append ?token=%s to the proxy URL to authenticate", token)
+		}
+		if err := serveHTTP(*serveAddr, *geometry, token, *servePublic); err != nil {
+			log.Fatalf("Interactive proxy server failed: %v", err)
+		}
+		return
+	}
+
+	encoder, err := newImageEncoder(*imageFormat)
+	if err != nil {
+		log.Fatalf("Invalid -image-format: %v", err)
+	}
+	processOpts := ProcessOptions{
+		Encoder:          encoder,
+		EncodeOpts:       EncodeOpts{Quality: *jpgQuality, Colors: *colors, MaxWidth: *maxWidth},
+		HarEnabled:       *harEnabled,
+		DismissSelectors: *dismissSelectors,
+		UserScriptPath:   *userScriptPath,
+	}
+
+	// -mode enumerate 下针对站点注册表检测用户名是否已被注册
+	if *mode == "enumerate" {
+		if *username == "" {
+			fmt.Println("Please provide a username using the -username flag")
+			printHelp()
+			os.Exit(1)
+		}
+		enumResults, err := runEnumerateMode(*username, *sitesFile, *concurrency, *timeout, *maxRetries, processOpts)
+		if err != nil {
+			log.Fatalf("Enumerate mode failed: %v", err)
+		}
+		generateEnumerateReport(*username, enumResults)
+		return
+	}
+
 	// 检查是否提供了文件路径
 	if *urlFile == "" {
 		fmt.Println("Please provide a file path using the -file flag")
@@ -65,8 +128,41 @@ func main() {
 		log.Fatalf("Error reading URLs from file: %v", err)
 	}
 
+	// 如果指定了 -checkpoint，加载之前已完成的结果并跳过对应的 URL
+	doneResults := make(map[string]Result)
+	var cw *checkpointWriter
+	if *checkpointPath != "" {
+		doneResults, err = loadCheckpoint(*checkpointPath)
+		if err != nil {
+			log.Fatalf("Error loading checkpoint: %v", err)
+		}
+		if len(doneResults) > 0 {
+			log.Printf("Resuming from checkpoint: %d URL(s) already processed", len(doneResults))
+		}
+		cw, err = newCheckpointWriter(*checkpointPath)
+		if err != nil {
+			log.Fatalf("Error opening checkpoint: %v", err)
+		}
+		defer cw.close()
+	}
+
+	var pendingURLs []string
+	for _, url := range urls {
+		if _, ok := doneResults[url]; !ok {
+			pendingURLs = append(pendingURLs, url)
+		}
+	}
+
 	// 处理 URLs
-	results := processURLsConcurrently(urls, *concurrency, *timeout, *maxRetries)
+	newResults := processURLsConcurrently(pendingURLs, *concurrency, *timeout, *maxRetries, processOpts, cw)
+
+	results := make([]Result, 0, len(urls))
+	for _, url := range urls {
+		if result, ok := doneResults[url]; ok {
+			results = append(results, result)
+		}
+	}
+	results = append(results, newResults...)
 
 	// 计算汇总信息
 	totalURLs := len(results)
@@ -91,11 +187,25 @@ func main() {
 	fmt.Printf("无法访问 URL 数: %d\n", inaccessibleURLs)
 	fmt.Printf("发生重定向的 URL 数: %d\n", redirectedURLs)
 
-	// 生成 HTML 报告，传入汇总信息
-	generateHTMLReport(results, totalURLs, accessibleURLs, inaccessibleURLs, redirectedURLs)
-
-	// 清理 Chrome 进程
-	cleanupChrome()
+	// 根据 -output-format 生成最终报告，对 json/csv/jsonl 而言这是一次基于汇总结果的后处理步骤
+	switch *outputFormat {
+	case "html":
+		generateHTMLReport(results, totalURLs, accessibleURLs, inaccessibleURLs, redirectedURLs)
+	case "json":
+		if err := writeJSONReport(results, "results.json"); err != nil {
+			log.Fatalf("Failed to write JSON report: %v", err)
+		}
+	case "csv":
+		if err := writeCSVReport(results, "results.csv"); err != nil {
+			log.Fatalf("Failed to write CSV report: %v", err)
+		}
+	case "jsonl":
+		if err := writeJSONLReport(results, "results.jsonl"); err != nil {
+			log.Fatalf("Failed to write JSONL report: %v", err)
+		}
+	default:
+		log.Fatalf("Invalid -output-format: %s", *outputFormat)
+	}
 }
 
 func printHelp() {
@@ -110,6 +220,38 @@ func printHelp() {
 	fmt.Println("        Timeout for each URL (default 180s)")
 	fmt.Println("  -max-retries int")
 	fmt.Println("        Maximum number of retries for each URL (default 3)")
+	fmt.Println("  -image-format string")
+	fmt.Println("        Screenshot encoding format: png, jpg or gif (default \"png\")")
+	fmt.Println("  -jpg-quality int")
+	fmt.Println("        JPEG quality (1-100) when -image-format=jpg (default 85)")
+	fmt.Println("  -colors int")
+	fmt.Println("        Palette size (2-256) when -image-format=gif (default 256)")
+	fmt.Println("  -max-width int")
+	fmt.Println("        Downscale screenshots to this width in pixels before encoding (0 disables)")
+	fmt.Println("  -har")
+	fmt.Println("        Capture network events and export a HAR file per URL under har/ (off by default for performance)")
+	fmt.Println("  -mode string")
+	fmt.Println("        Operating mode: leave empty for batch URL checking, or \"enumerate\" for username enumeration")
+	fmt.Println("  -username string")
+	fmt.Println("        Username to look up across sites, required when -mode=enumerate")
+	fmt.Println("  -sites string")
+	fmt.Println("        Path to the site registry JSON file, used when -mode=enumerate (default \"sites.json\")")
+	fmt.Println("  -dismiss-selectors string")
+	fmt.Println("        Comma-separated CSS selectors to click after page load, e.g. for cookie/consent banners")
+	fmt.Println("  -user-script string")
+	fmt.Println("        Path to a JS file evaluated on every page for custom dialog/banner dismissal")
+	fmt.Println("  -output-format string")
+	fmt.Println("        Report output format: html, json, csv or jsonl (default \"html\")")
+	fmt.Println("  -checkpoint string")
+	fmt.Println("        Path to a JSONL checkpoint file; URLs already recorded there are skipped, and each finished URL is appended to it as it completes")
+	fmt.Println("  -serve string")
+	fmt.Println("        Run as an interactive browsing proxy on this address (e.g. :8080) instead of batch mode")
+	fmt.Println("  -geometry string")
+	fmt.Println("        Screen geometry for -serve mode, as WxHxColors (default \"1280x1024x256\")")
+	fmt.Println("  -serve-token string")
+	fmt.Println("        Access token required by -serve mode (auto-generated and printed if left empty)")
+	fmt.Println("  -serve-public")
+	fmt.Println("        Allow -serve to bind all network interfaces instead of localhost only (dangerous: exposes an unauthenticated-by-network SSRF-capable proxy)")
 	fmt.Println("  -help")
 	fmt.Println("        Show this help information")
 	fmt.Println("\nExample:")
@@ -183,21 +325,35 @@ func convertToUTF8(content []byte) ([]byte, error) {
 	return content, nil
 }
 
-func processURLsConcurrently(urls []string, concurrency int, timeout time.Duration, maxRetries int) []Result {
+// ProcessOptions 收集控制单次渲染行为的各类可选参数，避免 processURL 系列函数的参数列表无限增长。
+type ProcessOptions struct {
+	Encoder          ImageEncoder
+	EncodeOpts       EncodeOpts
+	HarEnabled       bool
+	DismissSelectors string
+	UserScriptPath   string
+}
+
+func processURLsConcurrently(urls []string, concurrency int, timeout time.Duration, maxRetries int, opts ProcessOptions, cw *checkpointWriter) []Result {
 	resultsChan := make(chan Result, len(urls))
 	var wg sync.WaitGroup
 
-	// 创建一个带缓冲的通道来限制并发数
-	semaphore := make(chan struct{}, concurrency)
+	// 创建共享的 Chrome 标签页池，池的大小即为并发上限
+	pool := newTabPool(concurrency)
+	installShutdownHandler(pool)
+	defer pool.close()
 
 	for _, url := range urls {
 		wg.Add(1)
 		go func(url string) {
 			defer wg.Done()
-			semaphore <- struct{}{} // 获取信号量
-			result := processURLWithRetry(url, maxRetries, timeout)
+			result := processURLWithRetry(url, maxRetries, timeout, opts, pool)
+			if cw != nil {
+				if err := cw.append(result); err != nil {
+					log.Printf("Failed to append checkpoint for %s: %v", url, err)
+				}
+			}
 			resultsChan <- result
-			<-semaphore // 释放信号量
 		}(url)
 	}
 
@@ -214,10 +370,10 @@ func processURLsConcurrently(urls []string, concurrency int, timeout time.Durati
 	return results
 }
 
-func processURLWithRetry(url string, maxRetries int, timeout time.Duration) Result {
+func processURLWithRetry(url string, maxRetries int, timeout time.Duration, opts ProcessOptions, pool *tabPool) Result {
 	var result Result
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		result = processURL(url, timeout)
+		result = processURL(url, timeout, opts, pool)
 		if result.StatusCode != -1 && result.Screenshot != "" {
 			return result
 		}
@@ -227,7 +383,7 @@ func processURLWithRetry(url string, maxRetries int, timeout time.Duration) Resu
 	return result
 }
 
-func processURL(url string, timeout time.Duration) Result {
+func processURL(url string, timeout time.Duration, opts ProcessOptions, pool *tabPool) Result {
 	result := Result{OriginalURL: url}
 	url = ensureProtocol(url)
 
@@ -263,33 +419,50 @@ func processURL(url string, timeout time.Duration) Result {
 	result.WasRedirected = (result.URL != result.OriginalURL)
 
 	// 只有当 URL 可访问时，才进行截图和标题获取
-	// 创建新的Chrome实例
-	opts := append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.Flag("ignore-certificate-errors", true),
-		chromedp.Flag("disable-web-security", true),
-		chromedp.Flag("no-sandbox", true),
-		chromedp.Flag("disable-popup-blocking", true),
-		chromedp.Flag("disable-extensions", true),
-	)
-	allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
-	defer cancel()
+	// 从共享标签页池借出一个标签页，用完后重置并归还
+	tabCtx, ok := pool.checkout()
+	if !ok {
+		log.Printf("Tab pool is shutting down, aborting in-flight request for %s", url)
+		result.StatusCode = -1
+		return result
+	}
+	defer pool.checkin(tabCtx)
 
-	ctx, cancel := chromedp.NewContext(allocCtx)
+	ctx, cancel := context.WithTimeout(tabCtx, timeout)
 	defer cancel()
 
-	ctx, cancel = context.WithTimeout(ctx, timeout)
-	defer cancel()
+	var nl *networkLog
+	if opts.HarEnabled {
+		nl = newNetworkLog()
+		nl.listen(ctx)
+	}
+
+	dismissed := &dismissedEvents{}
+	listenForDialogs(ctx, dismissed)
 
 	var buf []byte
 	var title string
 	var finalURL string
-	err = chromedp.Run(ctx,
+	actions := []chromedp.Action{
 		chromedp.EmulateViewport(1280, 1024),
-		network.Enable(),
+	}
+	if opts.HarEnabled {
+		// 只有启用 HAR 导出时才打开 CDP Network 域，否则 chromedp 要白白
+		// 接收并派发每个子资源的网络事件，拖慢默认情况下的吞吐。
+		actions = append(actions, network.Enable())
+	}
+	actions = append(actions,
+		page.Enable(),
 		chromedp.Navigate(url),
 		chromedp.ActionFunc(func(ctx context.Context) error {
 			return waitForPageLoad(ctx)
 		}),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			return dismissBanners(ctx, opts.DismissSelectors, dismissed)
+		}),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			return runUserScript(ctx, opts.UserScriptPath)
+		}),
 		chromedp.Sleep(5*time.Second), // 添加额外的等待时间
 		chromedp.ActionFunc(func(ctx context.Context) error {
 			return waitForPageStable(ctx)
@@ -298,17 +471,35 @@ func processURL(url string, timeout time.Duration) Result {
 		chromedp.CaptureScreenshot(&buf),
 		chromedp.Title(&title),
 	)
+	err = chromedp.Run(ctx, actions...)
 	if err != nil {
 		log.Printf("Failed to capture screenshot or title for %s: %v", url, err)
+	} else {
+		result.URL = finalURL
 	}
-
-	result.URL = finalURL
 	result.WasRedirected = (result.URL != result.OriginalURL)
+	result.DialogsDismissed = dismissed.Dialogs
+	result.BannersDismissed = dismissed.Banners
+
+	if nl != nil {
+		result.NetworkRequests, result.NetworkBytes, result.NetworkErrors = nl.summary()
+		if harPath, err := nl.writeHAR(url); err != nil {
+			log.Printf("Failed to write HAR for %s: %v", url, err)
+		} else {
+			result.HARPath = harPath
+		}
+	}
 
 	if len(buf) > 0 {
-		result.Screenshot = base64.StdEncoding.EncodeToString(buf)
+		encoded, mime, err := opts.Encoder.Encode(buf, opts.EncodeOpts)
+		if err != nil {
+			log.Printf("Failed to encode screenshot for %s: %v", url, err)
+		} else {
+			result.Screenshot = base64.StdEncoding.EncodeToString(encoded)
+			result.ScreenshotMIME = mime
+			log.Printf("Screenshot captured for %s. Size: %d bytes", url, len(encoded))
+		}
 		result.Title = title
-		log.Printf("Screenshot captured for %s. Size: %d bytes", url, len(buf))
 	} else {
 		log.Printf("Screenshot buffer is empty for %s", url)
 	}
@@ -492,6 +683,7 @@ func generateHTMLReport(results []Result, totalURLs, accessibleURLs, inaccessibl
             <th class="title-column">标题</th>
             <th class="status-column">状态码</th>
             <th class="screenshot-column">截图</th>
+            <th>网络请求</th>
         </tr>
 `
 
@@ -502,7 +694,11 @@ func generateHTMLReport(results []Result, totalURLs, accessibleURLs, inaccessibl
 			accessibleCount++
 			var screenshotHTML string
 			if result.Screenshot != "" {
-				screenshotHTML = fmt.Sprintf(`<img class="screenshot" src="data:image/png;base64,%s" alt="Screenshot" onclick="showFullscreen(this)">`, result.Screenshot)
+				mime := result.ScreenshotMIME
+				if mime == "" {
+					mime = "image/png"
+				}
+				screenshotHTML = fmt.Sprintf(`<img class="screenshot" src="data:%s;base64,%s" alt="Screenshot" onclick="showFullscreen(this)">`, mime, result.Screenshot)
 			} else {
 				screenshotHTML = "No screenshot available..."
 			}
@@ -512,6 +708,17 @@ func generateHTMLReport(results []Result, totalURLs, accessibleURLs, inaccessibl
 				redirectInfo = fmt.Sprintf(`<br><span class="redirected">Redirected to: %s</span>`, result.URL)
 			}
 
+			networkInfo := ""
+			if result.HARPath != "" {
+				networkInfo = fmt.Sprintf(`%d requests, %d bytes, %d errors<br><a href="%s" target="_blank">HAR</a>`,
+					result.NetworkRequests, result.NetworkBytes, result.NetworkErrors, result.HARPath)
+			}
+
+			title := result.Title
+			if result.DialogsDismissed > 0 || result.BannersDismissed > 0 {
+				title += fmt.Sprintf(`<br><small>Dismissed %d dialog(s), %d banner(s)</small>`, result.DialogsDismissed, result.BannersDismissed)
+			}
+
 			htmlContent += fmt.Sprintf(`
         <tr>
             <td>%d</td>
@@ -524,8 +731,9 @@ func generateHTMLReport(results []Result, totalURLs, accessibleURLs, inaccessibl
             <td class="screenshot-column">
                 %s
             </td>
+            <td>%s</td>
         </tr>
-`, accessibleCount, result.URL, result.OriginalURL, redirectInfo, result.Title, result.StatusCode, screenshotHTML)
+`, accessibleCount, result.URL, result.OriginalURL, redirectInfo, title, result.StatusCode, screenshotHTML, networkInfo)
 		} else {
 			inaccessibleURLsList = append(inaccessibleURLsList, result.OriginalURL)
 		}
@@ -572,22 +780,3 @@ func generateHTMLReport(results []Result, totalURLs, accessibleURLs, inaccessibl
 
 	fmt.Println("Results saved to results.html")
 }
-
-func cleanupChrome() {
-	var cmd *exec.Cmd
-	switch goruntime.GOOS {
-	case "windows":
-		cmd = exec.Command("taskkill", "/F", "/IM", "chrome.exe")
-	case "darwin":
-		cmd = exec.Command("pkill", "Chrome")
-	default: // linux and others
-		cmd = exec.Command("pkill", "chrome")
-	}
-
-	err := cmd.Run()
-	if err != nil {
-		log.Printf("Failed to kill Chrome processes: %v", err)
-	} else {
-		log.Println("Successfully cleaned up Chrome processes")
-	}
-}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// checkpointWriter 在每个 URL 处理完成后立即把结果追加写入 checkpoint 文件，
+// 使一次被中断的大规模抓取可以在重启后跳过已完成的 URL。
+type checkpointWriter struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+func newCheckpointWriter(path string) (*checkpointWriter, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open checkpoint file: %w", err)
+	}
+	return &checkpointWriter{f: f}, nil
+}
+
+// append 将一条结果序列化为一行 JSON 并写入 checkpoint 文件。
+// 单次 Write 调用在 POSIX 系统上对小于 PIPE_BUF 的数据是原子的，足以保证单行不会与其他 worker 交错写坏。
+func (c *checkpointWriter) append(result Result) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err = c.f.Write(data)
+	return err
+}
+
+func (c *checkpointWriter) close() error {
+	return c.f.Close()
+}
+
+// loadCheckpoint 读取已存在的 checkpoint 文件，按 OriginalURL 索引之前已完成的结果，
+// 以便跳过重复抓取。
+func loadCheckpoint(path string) (map[string]Result, error) {
+	done := make(map[string]Result)
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open checkpoint file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var result Result
+		if err := json.Unmarshal(line, &result); err != nil {
+			log.Printf("Skipping malformed checkpoint line: %v", err)
+			continue
+		}
+		done[result.OriginalURL] = result
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint file: %w", err)
+	}
+
+	return done, nil
+}
+
+// writeJSONReport 把结果写为一个 JSON 数组文件。
+func writeJSONReport(results []Result, path string) error {
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write JSON report: %w", err)
+	}
+	fmt.Printf("Results saved to %s\n", path)
+	return nil
+}
+
+// writeJSONLReport 把结果写为 JSON Lines 文件，每行一条结果。
+func writeJSONLReport(results []Result, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create JSONL report: %w", err)
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	for _, result := range results {
+		if err := enc.Encode(result); err != nil {
+			return fmt.Errorf("failed to write JSONL report: %w", err)
+		}
+	}
+	fmt.Printf("Results saved to %s\n", path)
+	return nil
+}
+
+// writeCSVReport 把结果写为 CSV 文件，省略截图数据以保持文件可读。
+func writeCSVReport(results []Result, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create CSV report: %w", err)
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	defer w.Flush()
+
+	header := []string{"OriginalURL", "URL", "Title", "StatusCode", "WasRedirected", "NetworkRequests", "NetworkBytes", "NetworkErrors", "HARPath"}
+	if err := w.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	for _, result := range results {
+		row := []string{
+			result.OriginalURL,
+			result.URL,
+			result.Title,
+			strconv.Itoa(result.StatusCode),
+			strconv.FormatBool(result.WasRedirected),
+			strconv.Itoa(result.NetworkRequests),
+			strconv.FormatInt(result.NetworkBytes, 10),
+			strconv.Itoa(result.NetworkErrors),
+			result.HARPath,
+		}
+		if err := w.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+
+	fmt.Printf("Results saved to %s\n", path)
+	return nil
+}
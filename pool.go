@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// tabPool 维护一个共享的 Chrome ExecAllocator 以及固定数量的常驻标签页，
+// 供并发 worker 反复借用，避免每个 URL 都启动一个新的 Chrome 进程。
+//
+// mu/closed 保护 close() 与并发的 checkin() 之间的竞争：close() 在持有写锁
+// 时才标记 closed 并关闭 tabs channel，checkin() 只在持有读锁、确认池子还
+// 没关闭的情况下才会往 channel 发送，因此不会出现对已关闭 channel 发送导致
+// panic 的情况。
+type tabPool struct {
+	allocCtx context.Context
+	cancel   context.CancelFunc
+	tabs     chan context.Context
+	mu       sync.RWMutex
+	closed   bool
+}
+
+// newTabPool 启动一个共享的 ExecAllocator，并预先创建 size 个标签页上下文。
+func newTabPool(size int) *tabPool {
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Flag("ignore-certificate-errors", true),
+		chromedp.Flag("disable-web-security", true),
+		chromedp.Flag("no-sandbox", true),
+		chromedp.Flag("disable-popup-blocking", true),
+		chromedp.Flag("disable-extensions", true),
+	)
+	allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
+
+	pool := &tabPool{
+		allocCtx: allocCtx,
+		cancel:   cancel,
+		tabs:     make(chan context.Context, size),
+	}
+
+	for i := 0; i < size; i++ {
+		ctx, err := pool.newTab()
+		if err != nil {
+			log.Fatalf("Failed to start pooled tab: %v", err)
+		}
+		pool.tabs <- ctx
+	}
+
+	return pool
+}
+
+// newTab 在共享的 ExecAllocator 上创建并预热一个新的标签页上下文。
+func (p *tabPool) newTab() (context.Context, error) {
+	ctx, _ := chromedp.NewContext(p.allocCtx)
+	if err := chromedp.Run(ctx); err != nil {
+		return nil, err
+	}
+	return ctx, nil
+}
+
+// checkout 借出一个标签页上下文；调用方用完后必须调用 checkin 归还。
+// 第二个返回值在池子已经关闭（关闭中的 channel 被耗尽）时为 false，
+// 调用方此时必须放弃这次请求，不能再使用返回的 context。
+func (p *tabPool) checkout() (context.Context, bool) {
+	ctx, ok := <-p.tabs
+	return ctx, ok
+}
+
+// returnOrDiscard 在持有读锁的情况下尝试把 tab 放回池中；如果 close() 已经
+// 把池子标记为关闭，就丢弃这个 tab 而不是往可能已关闭的 channel 发送。
+func (p *tabPool) returnOrDiscard(ctx context.Context) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		closeTarget(ctx)
+		return
+	}
+	p.tabs <- ctx
+}
+
+// checkin 将标签页重置为空白页并清空 cookie，然后放回池中复用；
+// 如果重置失败就丢弃这个坏掉的 tab 并补充一个新的，保证池子里的
+// tab 数量不会越跑越少导致 checkout 永久阻塞。
+func (p *tabPool) checkin(ctx context.Context) {
+	p.mu.RLock()
+	closed := p.closed
+	p.mu.RUnlock()
+	if closed {
+		closeTarget(ctx)
+		return
+	}
+
+	if err := chromedp.Run(ctx,
+		chromedp.Navigate("about:blank"),
+		network.ClearBrowserCookies(),
+	); err != nil {
+		log.Printf("Failed to reset pooled tab, replacing it: %v", err)
+		closeTarget(ctx)
+		replacement, rerr := p.newTab()
+		if rerr != nil {
+			p.mu.RLock()
+			closed := p.closed
+			p.mu.RUnlock()
+			if closed {
+				log.Printf("Pool is shutting down, dropping tab instead of replacing: %v", rerr)
+				return
+			}
+			log.Fatalf("Failed to create replacement pooled tab: %v", rerr)
+		}
+		p.returnOrDiscard(replacement)
+		return
+	}
+	p.returnOrDiscard(ctx)
+}
+
+// closeTarget 关闭标签页对应的浏览器目标，用于彻底丢弃一个坏掉的 tab。
+func closeTarget(ctx context.Context) {
+	_ = chromedp.Run(ctx, page.Close())
+}
+
+// close 关闭所有标签页并取消共享的 ExecAllocator，确保不会留下孤儿 Chrome 进程。
+// 对并发的 checkin() 是安全的：一旦持有写锁标记 closed 并关闭 channel，
+// 任何还在运行的 checkin() 都会在其读锁保护的检查中看到 closed=true 并
+// 改为丢弃 tab，不会再向 channel 发送。
+func (p *tabPool) close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.tabs)
+	p.mu.Unlock()
+
+	for ctx := range p.tabs {
+		closeTarget(ctx)
+	}
+	p.cancel()
+}
+
+// installShutdownHandler 在收到 SIGINT/SIGTERM 时优雅地关闭标签池，
+// 替代过去依赖 pkill/taskkill 杀死残留 Chrome 进程的做法。
+func installShutdownHandler(pool *tabPool) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-sigCh
+		log.Printf("Received %v, shutting down Chrome pool...", sig)
+		pool.close()
+		os.Exit(1)
+	}()
+}
@@ -0,0 +1,408 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/input"
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	// maxServeSessions 限制同时存活的浏览会话数，避免每次 POST 都新开一个
+	// 永不回收的 Chrome 标签页，导致进程/内存无限增长。
+	maxServeSessions = 20
+	// sessionIdleTimeout 是会话允许的最长空闲时间，超时后由 sweep 回收。
+	sessionIdleTimeout = 10 * time.Minute
+	// sessionSweepInterval 是后台清理空闲会话的轮询间隔。
+	sessionSweepInterval = time.Minute
+)
+
+// tabSession 表示一个持久的浏览会话，对应一个常驻的 Chrome 标签页。
+type tabSession struct {
+	ID         string
+	ctx        context.Context
+	cancel     context.CancelFunc
+	mu         sync.Mutex
+	CurrentURL string
+	Width      int
+	Height     int
+	Colors     int
+	Token      string
+	screenshot []byte
+	lastUsed   time.Time
+}
+
+// sessionManager 按会话 ID 跟踪所有存活的浏览会话，并定期回收空闲会话。
+type sessionManager struct {
+	mu       sync.Mutex
+	sessions map[string]*tabSession
+	reserved int // 已通过容量检查、尚未（或不再）在 sessions 中的会话数，用于让 newSession 的检查与占位原子化
+	allocCtx context.Context
+	cancel   context.CancelFunc
+	stopCh   chan struct{}
+}
+
+func newSessionManager() *sessionManager {
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Flag("ignore-certificate-errors", true),
+		chromedp.Flag("disable-web-security", true),
+		chromedp.Flag("no-sandbox", true),
+		chromedp.Flag("disable-popup-blocking", true),
+	)
+	allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
+	sm := &sessionManager{
+		sessions: make(map[string]*tabSession),
+		allocCtx: allocCtx,
+		cancel:   cancel,
+		stopCh:   make(chan struct{}),
+	}
+	go sm.sweepLoop()
+	return sm
+}
+
+// sweepLoop 周期性地关闭超过 sessionIdleTimeout 未使用的会话。
+func (sm *sessionManager) sweepLoop() {
+	ticker := time.NewTicker(sessionSweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			sm.evictIdle()
+		case <-sm.stopCh:
+			return
+		}
+	}
+}
+
+func (sm *sessionManager) evictIdle() {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	for id, session := range sm.sessions {
+		if time.Since(session.idleSince()) > sessionIdleTimeout {
+			log.Printf("Closing idle browse session %s", id)
+			session.cancel()
+			delete(sm.sessions, id)
+			sm.reserved--
+		}
+	}
+}
+
+func newSessionID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// parseGeometry 解析 "WxHxColors" 形式的几何参数，例如 "1024x768x256"。
+func parseGeometry(geometry string) (width, height, colors int, err error) {
+	parts := strings.Split(geometry, "x")
+	if len(parts) != 3 {
+		return 0, 0, 0, fmt.Errorf("invalid geometry %q, expected WxHxColors", geometry)
+	}
+	if width, err = strconv.Atoi(parts[0]); err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid width in geometry %q: %v", geometry, err)
+	}
+	if height, err = strconv.Atoi(parts[1]); err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid height in geometry %q: %v", geometry, err)
+	}
+	if colors, err = strconv.Atoi(parts[2]); err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid colors in geometry %q: %v", geometry, err)
+	}
+	return width, height, colors, nil
+}
+
+func (sm *sessionManager) newSession(url string, width, height, colors int, token string) (*tabSession, error) {
+	sm.mu.Lock()
+	if sm.reserved >= maxServeSessions {
+		sm.mu.Unlock()
+		return nil, fmt.Errorf("too many active browse sessions (max %d), try again later", maxServeSessions)
+	}
+	sm.reserved++
+	sm.mu.Unlock()
+
+	ctx, cancel := chromedp.NewContext(sm.allocCtx)
+
+	session := &tabSession{
+		ID:         newSessionID(),
+		ctx:        ctx,
+		cancel:     cancel,
+		CurrentURL: url,
+		Width:      width,
+		Height:     height,
+		Colors:     colors,
+		Token:      token,
+		lastUsed:   time.Now(),
+	}
+
+	if err := session.navigate(url); err != nil {
+		cancel()
+		sm.mu.Lock()
+		sm.reserved--
+		sm.mu.Unlock()
+		return nil, err
+	}
+
+	sm.mu.Lock()
+	sm.sessions[session.ID] = session
+	sm.mu.Unlock()
+
+	return session, nil
+}
+
+func (sm *sessionManager) get(id string) *tabSession {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.sessions[id]
+}
+
+func (sm *sessionManager) closeAll() {
+	close(sm.stopCh)
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	for _, session := range sm.sessions {
+		session.cancel()
+	}
+	sm.cancel()
+}
+
+func (s *tabSession) render() error {
+	var buf []byte
+	if err := chromedp.Run(s.ctx,
+		chromedp.EmulateViewport(int64(s.Width), int64(s.Height)),
+		chromedp.CaptureScreenshot(&buf),
+	); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.screenshot = buf
+	s.lastUsed = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// idleSince 返回该会话最后一次被使用的时间，供 sweep 判断是否已超时。
+func (s *tabSession) idleSince() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastUsed
+}
+
+func (s *tabSession) navigate(url string) error {
+	url = ensureProtocol(url)
+	if err := chromedp.Run(s.ctx,
+		chromedp.EmulateViewport(int64(s.Width), int64(s.Height)),
+		chromedp.Navigate(url),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			return waitForPageLoad(ctx)
+		}),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			return waitForPageStable(ctx)
+		}),
+	); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.CurrentURL = url
+	s.mu.Unlock()
+	return s.render()
+}
+
+func (s *tabSession) click(x, y int64) error {
+	if err := chromedp.Run(s.ctx, chromedp.MouseClickXY(float64(x), float64(y))); err != nil {
+		return err
+	}
+	return s.render()
+}
+
+func (s *tabSession) scroll(deltaY float64) error {
+	if err := chromedp.Run(s.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		return input.DispatchMouseEvent(input.MouseWheel, 0, 0).WithDeltaY(deltaY).Do(ctx)
+	})); err != nil {
+		return err
+	}
+	return s.render()
+}
+
+func (s *tabSession) sendKeys(keys string) error {
+	if err := chromedp.Run(s.ctx, chromedp.KeyEvent(keys)); err != nil {
+		return err
+	}
+	return s.render()
+}
+
+func (s *tabSession) screenshotBytes() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.screenshot
+}
+
+var shellTemplate = template.Must(template.New("shell").Parse(`
+<!DOCTYPE html>
+<html>
+<head>
+    <title>CheckURL browse - {{.CurrentURL}}</title>
+</head>
+<body>
+    <form action="/browse/{{.ID}}/nav?token={{.Token}}" method="post">
+        <input type="text" name="url" value="{{.CurrentURL}}" size="60">
+        <input type="submit" value="Go">
+    </form>
+    <form action="/browse/{{.ID}}/scroll?token={{.Token}}" method="post" style="display:inline">
+        <button name="direction" value="up">Page Up</button>
+        <button name="direction" value="down">Page Down</button>
+    </form>
+    <form action="/browse/{{.ID}}/key?token={{.Token}}" method="post" style="display:inline">
+        <input type="text" name="keys" size="10">
+        <input type="submit" value="Send Key">
+    </form>
+    <p>
+    <form action="/browse/{{.ID}}/click?token={{.Token}}" method="post">
+        <input type="image" ismap name="click" src="/img/{{.ID}}.png?token={{.Token}}" alt="page render">
+    </form>
+</body>
+</html>
+`))
+
+func handleBrowse(sm *sessionManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rest := strings.TrimPrefix(r.URL.Path, "/browse/")
+		parts := strings.SplitN(rest, "/", 2)
+		id := parts[0]
+		action := ""
+		if len(parts) == 2 {
+			action = parts[1]
+		}
+
+		session := sm.get(id)
+		if session == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		var err error
+		switch action {
+		case "":
+			if err := shellTemplate.Execute(w, session); err != nil {
+				log.Printf("Failed to render shell template: %v", err)
+			}
+			return
+		case "nav":
+			err = session.navigate(r.FormValue("url"))
+		case "click":
+			x, _ := strconv.ParseInt(r.FormValue("click.x"), 10, 64)
+			y, _ := strconv.ParseInt(r.FormValue("click.y"), 10, 64)
+			err = session.click(x, y)
+		case "scroll":
+			if r.FormValue("direction") == "up" {
+				err = session.scroll(-float64(session.Height))
+			} else {
+				err = session.scroll(float64(session.Height))
+			}
+		case "key":
+			err = session.sendKeys(r.FormValue("keys"))
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		if err != nil {
+			log.Printf("Browse action %q failed for session %s: %v", action, id, err)
+		}
+		http.Redirect(w, r, "/browse/"+id+"?token="+session.Token, http.StatusSeeOther)
+	}
+}
+
+// requireToken 包装一个 handler，要求请求通过 "Authorization: Bearer <token>"
+// 请求头或 "token" 查询参数带上匹配的令牌，否则拒绝访问。这个代理会代表
+// 调用方驱动一个真实的 Chrome 标签页抓取任意 URL，没有认证就等于给公网
+// 开放了一个无鉴权的 SSRF/内网截图入口。
+func requireToken(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		supplied := r.URL.Query().Get("token")
+		if supplied == "" {
+			supplied = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(supplied), []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// resolveBindAddr 在 addr 没有显式指定回环地址时默认只绑定 127.0.0.1，
+// 无论调用方写的是裸 ":PORT" 还是 "0.0.0.0:PORT" 之类的非回环主机名，
+// 除非通过 public=true 显式要求绑定所有网络接口，这种情况下会打印一条
+// 醒目的警告，避免无人鉴权的浏览代理意外暴露在公网或内网其它主机可达的范围。
+func resolveBindAddr(addr string, public bool) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	if host == "127.0.0.1" || host == "localhost" || host == "::1" {
+		return addr
+	}
+	if !public {
+		log.Printf("WARNING: -serve address %q is not localhost-only; binding 127.0.0.1:%s instead. Pass -serve-public to bind all interfaces.", addr, port)
+		return "127.0.0.1:" + port
+	}
+	log.Printf("WARNING: -serve-public is set, binding %s on all network interfaces; anyone who can reach this port can drive a live Chrome tab through this host", addr)
+	return addr
+}
+
+func serveHTTP(addr string, geometry string, token string, public bool) error {
+	width, height, colors, err := parseGeometry(geometry)
+	if err != nil {
+		return err
+	}
+
+	sm := newSessionManager()
+	defer sm.closeAll()
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/", requireToken(token, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			url := r.FormValue("url")
+			session, err := sm.newSession(url, width, height, colors, token)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			http.Redirect(w, r, "/browse/"+session.ID+"?token="+token, http.StatusSeeOther)
+			return
+		}
+		fmt.Fprintf(w, `<form action="/?token=%s" method="post"><input type="text" name="url" placeholder="https://example.com" size="60"><input type="submit" value="Browse"></form>`, token)
+	}))
+
+	mux.HandleFunc("/browse/", requireToken(token, handleBrowse(sm)))
+
+	mux.HandleFunc("/img/", requireToken(token, func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/img/"), ".png")
+		session := sm.get(id)
+		if session == nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(session.screenshotBytes())
+	}))
+
+	bindAddr := resolveBindAddr(addr, public)
+	log.Printf("Interactive proxy listening on %s (token required)", bindAddr)
+	return http.ListenAndServe(bindAddr, mux)
+}